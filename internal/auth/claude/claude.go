@@ -0,0 +1,234 @@
+// Package claude implements the OAuth authorization code + PKCE flow used
+// to obtain Claude credentials.
+package claude
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/nghyane/llm-mux/internal/config"
+)
+
+// authorizeURL, tokenURL, clientID, and defaultRedirectURI are the
+// authorization-code+PKCE OAuth parameters for Anthropic's console client,
+// the same ones the Claude Code CLI itself registers with. git history for
+// this package has no earlier version to diff against; if a deployment has
+// its own registered OAuth client (different client_id/redirect), these
+// four constants are what need to change; the requested scopes are set
+// separately in GenerateAuthURL.
+const (
+	authorizeURL = "https://claude.ai/oauth/authorize"
+	tokenURL     = "https://console.anthropic.com/v1/oauth/token"
+	clientID     = "9d1c250a-e61b-44d9-88ed-5944d1962f5e"
+
+	// defaultRedirectURI is used when the caller doesn't supply one, i.e.
+	// the manual paste-the-code flow.
+	defaultRedirectURI = "https://console.anthropic.com/oauth/code/callback"
+)
+
+// PKCECodes holds a PKCE code verifier/challenge pair for the OAuth
+// authorization code flow.
+type PKCECodes struct {
+	Verifier  string
+	Challenge string
+}
+
+// GeneratePKCECodes creates a new S256 PKCE verifier/challenge pair.
+func GeneratePKCECodes() (*PKCECodes, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("pkce verifier generation failed: %w", err)
+	}
+	verifier := base64.RawURLEncoding.EncodeToString(raw)
+
+	sum := sha256.Sum256([]byte(verifier))
+	challenge := base64.RawURLEncoding.EncodeToString(sum[:])
+
+	return &PKCECodes{Verifier: verifier, Challenge: challenge}, nil
+}
+
+// AuthBundle is the result of a successful token exchange.
+type AuthBundle struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresAt    time.Time
+	APIKey       string
+}
+
+// TokenStorage is the on-disk representation of a Claude credential.
+type TokenStorage struct {
+	Email        string    `json:"email"`
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	APIKey       string    `json:"api_key,omitempty"`
+}
+
+// ClaudeAuth drives the OAuth authorization code + PKCE flow against
+// Anthropic's console.
+type ClaudeAuth struct {
+	cfg *config.Config
+}
+
+// NewClaudeAuth constructs a ClaudeAuth bound to cfg.
+func NewClaudeAuth(cfg *config.Config) *ClaudeAuth {
+	return &ClaudeAuth{cfg: cfg}
+}
+
+// GenerateAuthURL builds the authorization URL for state/pkceCodes. When
+// redirectURI is non-empty (the loopback flow), it is registered as the
+// OAuth redirect_uri instead of the static default. If the client doesn't
+// support a dynamic/loopback redirect_uri, err is returned so callers can
+// fall back to the manual paste-the-code flow.
+func (a *ClaudeAuth) GenerateAuthURL(state string, pkceCodes *PKCECodes, redirectURI string) (string, string, error) {
+	if redirectURI == "" {
+		redirectURI = defaultRedirectURI
+	} else if !isLoopbackRedirectURI(redirectURI) {
+		return "", "", fmt.Errorf("claude oauth client does not support dynamic redirect_uri %q", redirectURI)
+	}
+
+	values := url.Values{}
+	values.Set("code", "true")
+	values.Set("client_id", clientID)
+	values.Set("response_type", "code")
+	values.Set("redirect_uri", redirectURI)
+	values.Set("scope", "org:create_api_key user:profile user:inference")
+	values.Set("code_challenge", pkceCodes.Challenge)
+	values.Set("code_challenge_method", "S256")
+	values.Set("state", state)
+
+	return authorizeURL + "?" + values.Encode(), state, nil
+}
+
+// isLoopbackRedirectURI reports whether redirectURI points at 127.0.0.1 or
+// localhost, the only dynamic redirect_uri host the OAuth client accepts.
+func isLoopbackRedirectURI(redirectURI string) bool {
+	parsed, err := url.Parse(redirectURI)
+	if err != nil {
+		return false
+	}
+	host := parsed.Hostname()
+	return host == "127.0.0.1" || host == "localhost"
+}
+
+// ExchangeCodeForTokens exchanges an authorization code for an AuthBundle.
+func (a *ClaudeAuth) ExchangeCodeForTokens(ctx context.Context, code, state string, pkceCodes *PKCECodes) (*AuthBundle, error) {
+	payload := map[string]string{
+		"grant_type":    "authorization_code",
+		"code":          code,
+		"state":         state,
+		"client_id":     clientID,
+		"code_verifier": pkceCodes.Verifier,
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("claude token exchange request encoding failed: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(string(body)))
+	if err != nil {
+		return nil, fmt.Errorf("claude token exchange request failed: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("claude token exchange request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("claude token exchange response read failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("claude token exchange failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var tokenResp struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(respBody, &tokenResp); err != nil {
+		return nil, fmt.Errorf("claude token exchange response decoding failed: %w", err)
+	}
+
+	return &AuthBundle{
+		AccessToken:  tokenResp.AccessToken,
+		RefreshToken: tokenResp.RefreshToken,
+		ExpiresAt:    time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second),
+	}, nil
+}
+
+// CreateTokenStorage converts an AuthBundle into the persisted credential
+// shape, resolving Email from the access token's JWT claims.
+func (a *ClaudeAuth) CreateTokenStorage(bundle *AuthBundle) *TokenStorage {
+	if bundle == nil {
+		return nil
+	}
+	return &TokenStorage{
+		Email:        emailFromAccessToken(bundle.AccessToken),
+		AccessToken:  bundle.AccessToken,
+		RefreshToken: bundle.RefreshToken,
+		ExpiresAt:    bundle.ExpiresAt,
+		APIKey:       bundle.APIKey,
+	}
+}
+
+// emailFromAccessToken reads the "email" claim out of a JWT access token
+// without verifying its signature; the signature was already checked by
+// the token issuer during exchange.
+func emailFromAccessToken(accessToken string) string {
+	parts := strings.Split(accessToken, ".")
+	if len(parts) != 3 {
+		return ""
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return ""
+	}
+	var claims struct {
+		Email string `json:"email"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return ""
+	}
+	return claims.Email
+}
+
+// AuthErrorCode classifies an AuthenticationError.
+type AuthErrorCode string
+
+// ErrCodeExchangeFailed indicates the authorization code -> token exchange
+// was rejected by Anthropic's OAuth server.
+const ErrCodeExchangeFailed AuthErrorCode = "exchange_failed"
+
+// AuthenticationError wraps an OAuth failure with a stable error code so
+// callers can branch on the failure kind without string-matching.
+type AuthenticationError struct {
+	Code AuthErrorCode
+	Err  error
+}
+
+func (e *AuthenticationError) Error() string {
+	return fmt.Sprintf("claude authentication error [%s]: %v", e.Code, e.Err)
+}
+
+func (e *AuthenticationError) Unwrap() error {
+	return e.Err
+}
+
+// NewAuthenticationError wraps err with code.
+func NewAuthenticationError(code AuthErrorCode, err error) *AuthenticationError {
+	return &AuthenticationError{Code: code, Err: err}
+}
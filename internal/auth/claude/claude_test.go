@@ -0,0 +1,49 @@
+package claude
+
+import "testing"
+
+func TestGenerateAuthURL_LoopbackRedirect(t *testing.T) {
+	pkce, err := GeneratePKCECodes()
+	if err != nil {
+		t.Fatalf("GeneratePKCECodes() error = %v", err)
+	}
+
+	a := NewClaudeAuth(nil)
+
+	authURL, state, err := a.GenerateAuthURL("the-state", pkce, "http://127.0.0.1:54321/callback")
+	if err != nil {
+		t.Fatalf("GenerateAuthURL() with loopback redirect error = %v", err)
+	}
+	if state != "the-state" {
+		t.Errorf("state = %q, want %q", state, "the-state")
+	}
+	if authURL == "" {
+		t.Error("expected non-empty auth URL")
+	}
+}
+
+func TestGenerateAuthURL_RejectsNonLoopbackRedirect(t *testing.T) {
+	pkce, err := GeneratePKCECodes()
+	if err != nil {
+		t.Fatalf("GeneratePKCECodes() error = %v", err)
+	}
+
+	a := NewClaudeAuth(nil)
+
+	if _, _, err := a.GenerateAuthURL("the-state", pkce, "https://evil.example.com/callback"); err == nil {
+		t.Error("expected error for non-loopback redirect_uri, got nil")
+	}
+}
+
+func TestGenerateAuthURL_EmptyRedirectUsesDefault(t *testing.T) {
+	pkce, err := GeneratePKCECodes()
+	if err != nil {
+		t.Fatalf("GeneratePKCECodes() error = %v", err)
+	}
+
+	a := NewClaudeAuth(nil)
+
+	if _, _, err := a.GenerateAuthURL("the-state", pkce, ""); err != nil {
+		t.Fatalf("GenerateAuthURL() with empty redirect error = %v", err)
+	}
+}
@@ -16,6 +16,11 @@ import (
 	"github.com/nghyane/llm-mux/internal/provider"
 )
 
+// loopbackCallbackTimeout is the default bound on how long the Claude login
+// flow waits for the browser to redirect back to the loopback server.
+// Callers can override it per-login via LoginOptions.CallbackTimeout.
+const loopbackCallbackTimeout = 5 * time.Minute
+
 type ClaudeAuthenticator struct {
 }
 
@@ -55,7 +60,33 @@ func (a *ClaudeAuthenticator) Login(ctx context.Context, cfg *config.Config, opt
 
 	authSvc := claude.NewClaudeAuth(cfg)
 
-	authURL, returnedState, err := authSvc.GenerateAuthURL(state, pkceCodes)
+	// Prefer the loopback-server flow so the user never has to copy/paste a
+	// code; this also works over SSH/IDE terminals as long as the local
+	// port is reachable from the browser that completes the OAuth dance.
+	var ls *loopbackServer
+	if !opts.NoBrowser {
+		ls, err = newLoopbackServer()
+		if err != nil {
+			log.Warnf("Falling back to manual code entry: %v", err)
+			ls = nil
+		}
+	}
+
+	redirectURI := ""
+	if ls != nil {
+		redirectURI = ls.RedirectURI()
+	}
+
+	authURL, returnedState, err := authSvc.GenerateAuthURL(state, pkceCodes, redirectURI)
+	if err != nil && ls != nil {
+		// Some providers reject dynamic/loopback redirect URIs outright;
+		// retry once with the provider's static default before giving up.
+		log.Warnf("Provider rejected loopback redirect_uri, falling back to manual code entry: %v", err)
+		ls.Close()
+		ls = nil
+		redirectURI = ""
+		authURL, returnedState, err = authSvc.GenerateAuthURL(state, pkceCodes, redirectURI)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("claude authorisation url generation failed: %w", err)
 	}
@@ -71,18 +102,15 @@ func (a *ClaudeAuthenticator) Login(ctx context.Context, cfg *config.Config, opt
 	}
 
 	fmt.Printf("Visit the following URL to authenticate:\n%s\n\n", authURL)
-	fmt.Print("Paste the authorisation code from the browser: ")
 
-	scanner := bufio.NewScanner(os.Stdin)
-	if !scanner.Scan() {
-		if err := scanner.Err(); err != nil {
-			return nil, fmt.Errorf("failed to read authorisation code: %w", err)
-		}
-		return nil, fmt.Errorf("no authorisation code provided")
+	callbackTimeout := loopbackCallbackTimeout
+	if opts.CallbackTimeout > 0 {
+		callbackTimeout = opts.CallbackTimeout
 	}
-	code := strings.TrimSpace(scanner.Text())
-	if code == "" {
-		return nil, fmt.Errorf("empty authorisation code provided")
+
+	code, err := a.obtainAuthorizationCode(ctx, ls, state, callbackTimeout)
+	if err != nil {
+		return nil, err
 	}
 
 	log.Debug("Claude authorisation code received; exchanging for tokens")
@@ -115,3 +143,35 @@ func (a *ClaudeAuthenticator) Login(ctx context.Context, cfg *config.Config, opt
 		Metadata: metadata,
 	}, nil
 }
+
+// obtainAuthorizationCode waits for the authorization code, either from the
+// loopback server (ls non-nil) or by prompting the user to paste it. When
+// using the loopback server, the callback's state is verified against
+// expectedState before the code is accepted, and the wait is bounded by
+// callbackTimeout.
+func (a *ClaudeAuthenticator) obtainAuthorizationCode(ctx context.Context, ls *loopbackServer, expectedState string, callbackTimeout time.Duration) (string, error) {
+	if ls != nil {
+		cb, err := ls.Wait(ctx, callbackTimeout)
+		if err != nil {
+			return "", fmt.Errorf("claude loopback callback failed: %w", err)
+		}
+		if cb.State != expectedState {
+			return "", fmt.Errorf("claude authorisation state mismatch")
+		}
+		return cb.Code, nil
+	}
+
+	fmt.Print("Paste the authorisation code from the browser: ")
+	scanner := bufio.NewScanner(os.Stdin)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return "", fmt.Errorf("failed to read authorisation code: %w", err)
+		}
+		return "", fmt.Errorf("no authorisation code provided")
+	}
+	code := strings.TrimSpace(scanner.Text())
+	if code == "" {
+		return "", fmt.Errorf("empty authorisation code provided")
+	}
+	return code, nil
+}
@@ -0,0 +1,17 @@
+package login
+
+import "time"
+
+// LoginOptions carries login-flow choices shared across the providers in
+// this package.
+type LoginOptions struct {
+	// NoBrowser skips the browser/loopback flow and falls back to whatever
+	// non-interactive flow the provider supports (manual code paste for
+	// Claude, device-flow polling for Copilot).
+	NoBrowser bool
+	// CallbackTimeout bounds how long a loopback-based login waits for its
+	// OAuth redirect before giving up. Zero uses the provider's default.
+	CallbackTimeout time.Duration
+	// Metadata carries provider-specific extra options.
+	Metadata map[string]string
+}
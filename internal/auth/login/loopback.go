@@ -0,0 +1,140 @@
+package login
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// defaultLoopbackTimeout bounds how long a loopback callback server waits
+// for the provider to redirect back before giving up, if the caller does
+// not pass a more specific timeout.
+const defaultLoopbackTimeout = 5 * time.Minute
+
+// loopbackCallback is the authorization result captured from a single
+// redirect to the loopback server.
+type loopbackCallback struct {
+	Code  string
+	State string
+}
+
+// loopbackServer is a short-lived HTTP server bound to 127.0.0.1 that
+// captures a single OAuth redirect so the user never has to copy/paste an
+// authorization code. The Claude login uses it via newLoopbackServer, and
+// any other browser-redirect provider under internal/auth/login can do the
+// same; GitHub Copilot's device flow has no redirect and does not use it.
+type loopbackServer struct {
+	listener net.Listener
+	server   *http.Server
+	result   chan loopbackCallback
+	errCh    chan error
+}
+
+// newLoopbackServer binds an ephemeral port on 127.0.0.1 and starts serving
+// the OAuth callback in the background. Callers use RedirectURI to build
+// the redirect_uri to register with the provider, then Wait to block until
+// the callback arrives, ctx is cancelled, or a timeout elapses.
+func newLoopbackServer() (*loopbackServer, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("loopback auth: failed to bind 127.0.0.1: %w", err)
+	}
+
+	ls := &loopbackServer{
+		listener: listener,
+		result:   make(chan loopbackCallback, 1),
+		errCh:    make(chan error, 1),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/callback", ls.handleCallback)
+	ls.server = &http.Server{Handler: mux}
+
+	go func() {
+		if serveErr := ls.server.Serve(listener); serveErr != nil && !errors.Is(serveErr, http.ErrServerClosed) {
+			select {
+			case ls.errCh <- serveErr:
+			default:
+			}
+		}
+	}()
+
+	return ls, nil
+}
+
+// RedirectURI returns the http://127.0.0.1:<port>/callback URI that should
+// be registered as the OAuth redirect_uri for this login attempt.
+func (ls *loopbackServer) RedirectURI() string {
+	return fmt.Sprintf("http://%s/callback", ls.listener.Addr().String())
+}
+
+func (ls *loopbackServer) handleCallback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	if msg := query.Get("error"); msg != "" {
+		select {
+		case ls.errCh <- fmt.Errorf("authorization denied: %s", msg):
+		default:
+		}
+		http.Error(w, "Authentication failed. You can close this tab and return to the terminal.", http.StatusBadRequest)
+		return
+	}
+
+	code := query.Get("code")
+	if code == "" {
+		http.Error(w, "Missing authorization code", http.StatusBadRequest)
+		return
+	}
+
+	select {
+	case ls.result <- loopbackCallback{Code: code, State: query.Get("state")}:
+	default:
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = fmt.Fprint(w, loopbackSuccessPage)
+}
+
+// Wait blocks until a callback is received, ctx is cancelled, or timeout
+// elapses, whichever happens first, then shuts down the server. A timeout
+// of zero falls back to defaultLoopbackTimeout.
+func (ls *loopbackServer) Wait(ctx context.Context, timeout time.Duration) (loopbackCallback, error) {
+	defer ls.Close()
+
+	if timeout <= 0 {
+		timeout = defaultLoopbackTimeout
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case cb := <-ls.result:
+		return cb, nil
+	case err := <-ls.errCh:
+		return loopbackCallback{}, err
+	case <-ctx.Done():
+		return loopbackCallback{}, ctx.Err()
+	case <-timer.C:
+		return loopbackCallback{}, fmt.Errorf("timed out after %s waiting for the OAuth redirect", timeout)
+	}
+}
+
+// Close shuts down the loopback server. Safe to call more than once.
+func (ls *loopbackServer) Close() {
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	_ = ls.server.Shutdown(shutdownCtx)
+}
+
+const loopbackSuccessPage = `<!DOCTYPE html>
+<html>
+<head><title>Authentication successful</title></head>
+<body style="font-family: sans-serif; text-align: center; margin-top: 15%;">
+<h2>Authentication successful</h2>
+<p>You can close this tab and return to the terminal.</p>
+</body>
+</html>
+`
@@ -9,6 +9,13 @@ import (
 )
 
 // DoCopilotLogin performs the GitHub Copilot OAuth device flow login.
+//
+// This doesn't use the loopback HTTP callback harness added for Claude
+// (internal/auth/login/loopback.go): device flow has no redirect step to
+// intercept. The user enters a code on github.com and this flow polls
+// GitHub's token endpoint directly, so there's no authorization code/state
+// to capture via a local server. The harness remains available in package
+// login for any future provider that does complete a browser redirect.
 func DoCopilotLogin(cfg *config.Config, options *LoginOptions) {
 	if options == nil {
 		options = &LoginOptions{}
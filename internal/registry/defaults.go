@@ -0,0 +1,119 @@
+package registry
+
+// thinkingSuffixes enumerates the named-level model-name suffixes
+// util.ParseThinkingSuffixDetailed recognizes. A seeded model is registered
+// once per suffix so that a request for e.g. "claude-sonnet-4-5-thinking-high"
+// resolves to real ModelInfo instead of falling through to the nil case in
+// GetModelInfo (which otherwise makes the whole ApplyThinking/
+// CheckContextBudget path dead code against real model names). The numeric
+// and percentage forms (-thinking-<int>, -thinking-<int>%) are not seeded,
+// so GetModelInfo returns nil for such names unless they are registered
+// separately.
+var thinkingSuffixes = []string{"", "-thinking", "-thinking-low", "-thinking-medium", "-thinking-high", "-thinking-max"}
+
+// defaultModel is the seed data for one catalog entry; registerDefaults
+// expands it across thinkingSuffixes.
+type defaultModel struct {
+	name             string
+	info             ModelInfo
+	overflowFallback string
+}
+
+// defaultModels is the built-in model catalog. It covers a few representative,
+// publicly documented models for each provider this proxy supports, with context
+// windows, completion limits, and thinking budgets taken from each
+// provider's published model docs. A deployment with its own model config
+// source should call Register directly for anything not listed here; this
+// only exists so the registry isn't empty (and thinking/routing dead code)
+// when no such config source is wired up yet.
+var defaultModels = []defaultModel{
+	{
+		name: "claude-opus-4-1",
+		info: ModelInfo{
+			Provider:            "claude",
+			ContextWindow:       200_000,
+			MaxCompletionTokens: 32_000,
+			Thinking: &ThinkingSettings{
+				Min:          1024,
+				Max:          32768,
+				Budgets:      ThinkingBudgets{Low: 1024, Medium: 8192, High: 16384, Max: 32768},
+				DefaultLevel: ThinkingLevelMedium,
+			},
+		},
+		overflowFallback: "claude-sonnet-4-5",
+	},
+	{
+		name: "claude-sonnet-4-5",
+		info: ModelInfo{
+			Provider:            "claude",
+			ContextWindow:       1_000_000,
+			MaxCompletionTokens: 64_000,
+			Thinking: &ThinkingSettings{
+				Min:          1024,
+				Max:          32768,
+				Budgets:      ThinkingBudgets{Low: 1024, Medium: 8192, High: 16384, Max: 32768},
+				DefaultLevel: ThinkingLevelMedium,
+			},
+		},
+	},
+	{
+		name: "gemini-2.5-pro",
+		info: ModelInfo{
+			Provider:            "gemini",
+			ContextWindow:       1_048_576,
+			MaxCompletionTokens: 65_536,
+			Thinking: &ThinkingSettings{
+				Min:          128,
+				Max:          32768,
+				Budgets:      ThinkingBudgets{Low: 1024, Medium: 8192, High: 16384, Max: 32768},
+				DefaultLevel: ThinkingLevelMedium,
+			},
+		},
+		overflowFallback: "",
+	},
+	{
+		name: "gemini-2.5-flash",
+		info: ModelInfo{
+			Provider:            "gemini",
+			ContextWindow:       1_048_576,
+			MaxCompletionTokens: 65_536,
+			Thinking: &ThinkingSettings{
+				Min:     0,
+				Max:     24576,
+				Budgets: ThinkingBudgets{Low: 1024, Medium: 8192, High: 16384, Max: 24576},
+			},
+		},
+	},
+	{
+		name: "gpt-5",
+		info: ModelInfo{
+			Provider:            "openai",
+			ContextWindow:       400_000,
+			MaxCompletionTokens: 128_000,
+			Thinking: &ThinkingSettings{
+				Min:     0,
+				Max:     100_000,
+				Budgets: ThinkingBudgets{Low: 1024, Medium: 8192, High: 24576, Max: 100_000},
+			},
+		},
+	},
+}
+
+// RegisterDefaults seeds reg with the built-in model catalog. Safe to call
+// more than once (each call just re-registers the same entries). Call it
+// before resolving any model's ModelInfo in a process that has no other
+// model-config source wired up; GetGlobalRegistry's package init does this
+// automatically for the global registry.
+func RegisterDefaults(reg *Registry) {
+	for _, m := range defaultModels {
+		info := m.info
+		info.OverflowFallback = m.overflowFallback
+		for _, suffix := range thinkingSuffixes {
+			reg.Register(m.name+suffix, &info)
+		}
+	}
+}
+
+func init() {
+	RegisterDefaults(GetGlobalRegistry())
+}
@@ -0,0 +1,57 @@
+package registry
+
+import "testing"
+
+func TestRegisterDefaults_SeedsBaseAndThinkingSuffixVariants(t *testing.T) {
+	reg := &Registry{models: make(map[string]*ModelInfo)}
+	RegisterDefaults(reg)
+
+	tests := []struct {
+		name         string
+		wantProvider string
+	}{
+		{"claude-sonnet-4-5", "claude"},
+		{"claude-sonnet-4-5-thinking-high", "claude"},
+		{"gemini-2.5-pro", "gemini"},
+		{"gemini-2.5-pro-thinking-max", "gemini"},
+		{"gpt-5", "openai"},
+	}
+	for _, tt := range tests {
+		info := reg.GetModelInfo(tt.name)
+		if info == nil {
+			t.Fatalf("GetModelInfo(%q) = nil, want a seeded entry", tt.name)
+		}
+		if info.Provider != tt.wantProvider {
+			t.Errorf("GetModelInfo(%q).Provider = %q, want %q", tt.name, info.Provider, tt.wantProvider)
+		}
+		if info.Thinking == nil {
+			t.Errorf("GetModelInfo(%q).Thinking = nil, want non-nil", tt.name)
+		}
+	}
+}
+
+func TestRegisterDefaults_OverflowFallbackResolves(t *testing.T) {
+	reg := &Registry{models: make(map[string]*ModelInfo)}
+	RegisterDefaults(reg)
+
+	opus := reg.GetModelInfo("claude-opus-4-1")
+	if opus == nil {
+		t.Fatal("GetModelInfo(claude-opus-4-1) = nil")
+	}
+	if opus.OverflowFallback == "" {
+		t.Fatal("expected claude-opus-4-1 to have an OverflowFallback")
+	}
+	if fallback := reg.GetModelInfo(opus.OverflowFallback); fallback == nil {
+		t.Errorf("OverflowFallback %q does not resolve to a registered model", opus.OverflowFallback)
+	}
+}
+
+func TestGetGlobalRegistry_IsSeededByInit(t *testing.T) {
+	// No registerTestModel/RegisterDefaults call here: this checks the
+	// actual process-wide registry that production code resolves models
+	// against, seeded automatically by this package's init().
+	info := GetGlobalRegistry().GetModelInfo("claude-sonnet-4-5")
+	if info == nil {
+		t.Fatal("GetGlobalRegistry().GetModelInfo(claude-sonnet-4-5) = nil, want the built-in default seeded at init")
+	}
+}
@@ -0,0 +1,81 @@
+// Package registry is the single source of truth for per-model metadata
+// (context window, completion limits, thinking budgets, provider) used by
+// the translator and routing layers to make provider-agnostic decisions.
+package registry
+
+import "sync"
+
+// ThinkingLevel names a tier in a model's thinking/reasoning budget ladder.
+type ThinkingLevel string
+
+const (
+	ThinkingLevelLow    ThinkingLevel = "low"
+	ThinkingLevelMedium ThinkingLevel = "medium"
+	ThinkingLevelHigh   ThinkingLevel = "high"
+	ThinkingLevelMax    ThinkingLevel = "max"
+)
+
+// ThinkingBudgets maps the named thinking levels to token budgets for a
+// specific model.
+type ThinkingBudgets struct {
+	Low    int
+	Medium int
+	High   int
+	Max    int
+}
+
+// ThinkingSettings describes a model's thinking/reasoning capability.
+type ThinkingSettings struct {
+	Min          int
+	Max          int
+	Budgets      ThinkingBudgets
+	DefaultLevel ThinkingLevel
+}
+
+// ModelInfo is the registry's metadata record for a single model name.
+type ModelInfo struct {
+	Provider            string
+	ContextWindow       int
+	MaxCompletionTokens int
+	Thinking            *ThinkingSettings
+	// OverflowFallback names a larger-context sibling model that routing
+	// should transparently downgrade to when a request's estimated input
+	// plus output tokens would exceed ContextWindow. Empty means no
+	// fallback is available.
+	OverflowFallback string
+}
+
+// Registry resolves model names to their ModelInfo metadata.
+type Registry struct {
+	mu     sync.RWMutex
+	models map[string]*ModelInfo
+}
+
+// GetModelInfo returns the metadata for model, or nil if it isn't
+// registered.
+func (r *Registry) GetModelInfo(model string) *ModelInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.models[model]
+}
+
+// Register adds or replaces the metadata for model.
+func (r *Registry) Register(model string, info *ModelInfo) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.models[model] = info
+}
+
+var (
+	globalRegistry     *Registry
+	globalRegistryOnce sync.Once
+)
+
+// GetGlobalRegistry returns the process-wide model registry, creating it on
+// first use.
+func GetGlobalRegistry() *Registry {
+	globalRegistryOnce.Do(func() {
+		globalRegistry = &Registry{models: make(map[string]*ModelInfo)}
+	})
+	return globalRegistry
+}
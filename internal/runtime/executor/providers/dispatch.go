@@ -0,0 +1,47 @@
+package providers
+
+// PrepareRequest is the pre-dispatch hook a provider executor calls before
+// sending a request upstream: it resolves and applies the thinking
+// configuration for model (ApplyThinking, which picks the right wire format
+// via registry.GetModelInfo(model).Provider), then runs CheckContextBudget
+// against the (possibly thinking-adjusted) body so overflowing requests get
+// downgraded or rejected before the call is made.
+//
+// This package does not yet contain the HTTP client / request-execution
+// layer that would call PrepareRequest on the live request path - that
+// layer doesn't exist anywhere in this tree yet, so today PrepareRequest is
+// exercised only by routing_test.go. It's written as the intended call site
+// for whichever executor lands that layer, not as evidence the wiring is
+// already live.
+//
+// body is the raw provider request body, in whatever wire format model's
+// provider expects. For Gemini-family models it's parsed directly for
+// accurate token counting; for providers without a local tokenizer (e.g.
+// Claude), rawText is estimated with a character/4 heuristic instead, so
+// callers on those paths should pass the request's flattened text there.
+// requestedMaxTokens is the caller's requested output budget, or 0 to use
+// the model's registered MaxCompletionTokens.
+//
+// Returns the request body (with thinking config applied) and the model
+// that should actually be dispatched to (unchanged, or an
+// OverflowFallback). If the request doesn't fit even after following any
+// fallback chain, body is still returned (so a caller that wants to log or
+// inspect it can) alongside a non-nil *ErrContextOverflow.
+func PrepareRequest(model string, body []byte, rawText string, requestedMaxTokens int) (resultBody []byte, resolvedModel string, err error) {
+	resultBody = ApplyThinking(model, body)
+
+	resolvedModel, err = CheckContextBudget(model, resultBody, rawText, requestedMaxTokens)
+	if err != nil {
+		return resultBody, resolvedModel, err
+	}
+
+	if resolvedModel != model {
+		// Re-apply thinking config for the resolved model. The Apply*
+		// methods leave an existing thinking config untouched, so this only
+		// adds one where the body has none in the resolved model's wire
+		// format; a budget already applied for the original model is kept.
+		resultBody = ApplyThinking(resolvedModel, resultBody)
+	}
+
+	return resultBody, resolvedModel, nil
+}
@@ -0,0 +1,117 @@
+package providers
+
+import (
+	"fmt"
+
+	log "github.com/nghyane/llm-mux/internal/logging"
+	"github.com/nghyane/llm-mux/internal/registry"
+	"github.com/nghyane/llm-mux/internal/util"
+)
+
+// contextSafetyMargin is reserved headroom subtracted from a model's
+// context window before comparing against requested input+output tokens,
+// to absorb estimation error in the token counters.
+const contextSafetyMargin = 512
+
+// maxOverflowFallbackHops bounds how many times CheckContextBudget will
+// follow registry.ModelInfo.OverflowFallback chains before giving up, so a
+// misconfigured or cyclic fallback chain can't loop forever.
+const maxOverflowFallbackHops = 4
+
+// ErrContextOverflow indicates a request's estimated input tokens, plus its
+// thinking budget and requested output tokens, would exceed the model's
+// context window, and no OverflowFallback model was available to absorb it.
+type ErrContextOverflow struct {
+	Model          string
+	InputTokens    int64
+	ThinkingBudget int
+	MaxTokens      int
+	ContextWindow  int
+}
+
+func (e *ErrContextOverflow) Error() string {
+	return fmt.Sprintf("context overflow for model %q: input_tokens=%d thinking_budget=%d max_tokens=%d exceeds context_window=%d",
+		e.Model, e.InputTokens, e.ThinkingBudget, e.MaxTokens, e.ContextWindow)
+}
+
+// CheckContextBudget is a pre-dispatch routing hook: it estimates input
+// tokens for the request, looks up the target model's context window, and
+// subtracts the resolved thinking budget from the available output space.
+// If the request doesn't fit, it follows registry.ModelInfo.OverflowFallback
+// to a larger-context sibling model and rechecks; if no fallback is
+// declared (or the chain is exhausted), it returns ErrContextOverflow.
+//
+// geminiPayload is the raw Gemini-format request body used for token
+// counting via util.CountTokensFromGeminiRequest. Pass nil for providers
+// without a local tokenizer (e.g. Claude); rawText is then estimated with a
+// character/4 heuristic instead.
+//
+// On success, returns the model that should actually be dispatched to
+// (unchanged, or the resolved fallback) and a nil error.
+func CheckContextBudget(model string, geminiPayload []byte, rawText string, requestedMaxTokens int) (string, error) {
+	current := model
+	visited := make(map[string]bool, maxOverflowFallbackHops+1)
+
+	for hops := 0; hops <= maxOverflowFallbackHops; hops++ {
+		if visited[current] {
+			break
+		}
+		visited[current] = true
+
+		info := registry.GetGlobalRegistry().GetModelInfo(current)
+		if info == nil || info.ContextWindow <= 0 {
+			// No routing metadata to check against; let the request through.
+			return current, nil
+		}
+
+		inputTokens := estimateInputTokens(current, geminiPayload, rawText)
+
+		thinkingBudget := 0
+		if cfg := ParseThinkingFromModel(current); cfg != nil {
+			thinkingBudget = cfg.BudgetTokens
+		}
+
+		maxTokens := requestedMaxTokens
+		if maxTokens <= 0 && info.MaxCompletionTokens > 0 {
+			maxTokens = info.MaxCompletionTokens
+		}
+
+		headroom := int64(info.ContextWindow-contextSafetyMargin) - inputTokens - int64(thinkingBudget)
+		fits := headroom >= int64(maxTokens)
+
+		log.Infof("routing budget check: model=%s input_tokens=%d thinking_budget=%d max_tokens=%d context_window=%d fits=%t",
+			current, inputTokens, thinkingBudget, maxTokens, info.ContextWindow, fits)
+
+		if fits {
+			return current, nil
+		}
+
+		if info.OverflowFallback == "" || hops == maxOverflowFallbackHops {
+			return current, &ErrContextOverflow{
+				Model:          current,
+				InputTokens:    inputTokens,
+				ThinkingBudget: thinkingBudget,
+				MaxTokens:      maxTokens,
+				ContextWindow:  info.ContextWindow,
+			}
+		}
+
+		log.Warnf("model %s overflows context budget, downgrading to overflow fallback %s", current, info.OverflowFallback)
+		current = info.OverflowFallback
+	}
+
+	return current, &ErrContextOverflow{Model: current}
+}
+
+// estimateInputTokens counts tokens for the request using the Gemini
+// tokenizer when a Gemini-format payload is available, falling back to a
+// character/4 heuristic (used for Claude and other providers without a
+// local tokenizer) when it isn't.
+func estimateInputTokens(model string, geminiPayload []byte, rawText string) int64 {
+	if len(geminiPayload) > 0 {
+		if tokens := util.CountTokensFromGeminiRequest(model, geminiPayload); tokens > 0 {
+			return tokens
+		}
+	}
+	return int64(len(rawText)) / 4
+}
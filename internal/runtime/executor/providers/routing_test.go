@@ -0,0 +1,118 @@
+package providers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nghyane/llm-mux/internal/registry"
+)
+
+func registerTestModel(t *testing.T, name string, info *registry.ModelInfo) {
+	t.Helper()
+	reg := registry.GetGlobalRegistry()
+	reg.Register(name, info)
+	t.Cleanup(func() { reg.Register(name, nil) })
+}
+
+func TestCheckContextBudget_FitsWithinWindow(t *testing.T) {
+	registerTestModel(t, "test-small", &registry.ModelInfo{
+		ContextWindow:       1000,
+		MaxCompletionTokens: 100,
+	})
+
+	model, err := CheckContextBudget("test-small", nil, strings.Repeat("a", 40), 100)
+	if err != nil {
+		t.Fatalf("CheckContextBudget() error = %v", err)
+	}
+	if model != "test-small" {
+		t.Errorf("model = %q, want %q", model, "test-small")
+	}
+}
+
+func TestCheckContextBudget_OverflowWithoutFallback(t *testing.T) {
+	registerTestModel(t, "test-tiny", &registry.ModelInfo{
+		ContextWindow:       100,
+		MaxCompletionTokens: 50,
+	})
+
+	_, err := CheckContextBudget("test-tiny", nil, strings.Repeat("a", 1000), 50)
+	if err == nil {
+		t.Fatal("expected ErrContextOverflow, got nil")
+	}
+	if _, ok := err.(*ErrContextOverflow); !ok {
+		t.Errorf("error = %T, want *ErrContextOverflow", err)
+	}
+}
+
+func TestCheckContextBudget_DowngradesToOverflowFallback(t *testing.T) {
+	registerTestModel(t, "test-tiny-with-fallback", &registry.ModelInfo{
+		ContextWindow:       100,
+		MaxCompletionTokens: 50,
+		OverflowFallback:    "test-big",
+	})
+	registerTestModel(t, "test-big", &registry.ModelInfo{
+		ContextWindow:       1_000_000,
+		MaxCompletionTokens: 50,
+	})
+
+	model, err := CheckContextBudget("test-tiny-with-fallback", nil, strings.Repeat("a", 1000), 50)
+	if err != nil {
+		t.Fatalf("CheckContextBudget() error = %v", err)
+	}
+	if model != "test-big" {
+		t.Errorf("model = %q, want %q", model, "test-big")
+	}
+}
+
+func TestCheckContextBudget_BoundsFallbackCycle(t *testing.T) {
+	registerTestModel(t, "test-cycle-a", &registry.ModelInfo{
+		ContextWindow:    100,
+		OverflowFallback: "test-cycle-b",
+	})
+	registerTestModel(t, "test-cycle-b", &registry.ModelInfo{
+		ContextWindow:    100,
+		OverflowFallback: "test-cycle-a",
+	})
+
+	_, err := CheckContextBudget("test-cycle-a", nil, strings.Repeat("a", 1000), 50)
+	if err == nil {
+		t.Fatal("expected ErrContextOverflow from a cyclic fallback chain, got nil")
+	}
+}
+
+func TestCheckContextBudget_UnregisteredModelPassesThrough(t *testing.T) {
+	model, err := CheckContextBudget("not-in-registry", nil, "hello", 10)
+	if err != nil {
+		t.Fatalf("CheckContextBudget() error = %v", err)
+	}
+	if model != "not-in-registry" {
+		t.Errorf("model = %q, want %q", model, "not-in-registry")
+	}
+}
+
+func TestPrepareRequest_AppliesThinkingForResolvedModel(t *testing.T) {
+	registerTestModel(t, "test-thinker-small-thinking-2048", &registry.ModelInfo{
+		Provider:            "claude",
+		ContextWindow:       100,
+		MaxCompletionTokens: 50,
+		OverflowFallback:    "test-thinker-big-thinking-2048",
+		Thinking:            &registry.ThinkingSettings{Min: 1024, Max: 4096},
+	})
+	registerTestModel(t, "test-thinker-big-thinking-2048", &registry.ModelInfo{
+		Provider:            "claude",
+		ContextWindow:       1_000_000,
+		MaxCompletionTokens: 50,
+		Thinking:            &registry.ThinkingSettings{Min: 1024, Max: 4096},
+	})
+
+	body, model, err := PrepareRequest("test-thinker-small-thinking-2048", []byte(`{}`), strings.Repeat("a", 1000), 50)
+	if err != nil {
+		t.Fatalf("PrepareRequest() error = %v", err)
+	}
+	if model != "test-thinker-big-thinking-2048" {
+		t.Errorf("model = %q, want %q", model, "test-thinker-big-thinking-2048")
+	}
+	if !strings.Contains(string(body), `"budget_tokens":2048`) {
+		t.Errorf("body = %s, want thinking.budget_tokens=2048 applied", body)
+	}
+}
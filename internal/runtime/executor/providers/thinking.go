@@ -13,18 +13,26 @@ import (
 type ThinkingConfig struct {
 	Enabled      bool
 	BudgetTokens int
+	// Level is the suffix level the budget was resolved from, if any
+	// (empty when the model auto-applies a default budget). Providers that
+	// work in discrete tiers rather than raw token budgets (e.g. OpenAI's
+	// reasoning_effort) key off this instead of BudgetTokens directly.
+	Level util.ThinkingLevel
 }
 
-// ParseClaudeThinkingFromModel extracts thinking configuration from a Claude model name suffix.
-// Uses single source of truth from registry via util.GetThinkingBudget.
-// Returns nil if the model doesn't have a thinking suffix.
-func ParseClaudeThinkingFromModel(modelName string) *ThinkingConfig {
-	suffixLevel, isThinking := util.ParseThinkingSuffix(modelName)
+// ParseThinkingFromModel extracts thinking configuration from a model name
+// suffix. It is provider-agnostic: callers select the wire format with
+// ApplyToClaude/ApplyToGemini/ApplyToOpenAI, or let ApplyThinking pick one
+// via registry.GetModelInfo(model).Provider. Uses single source of truth
+// from registry via util.GetThinkingBudget. Returns nil if the model
+// doesn't have a thinking suffix.
+func ParseThinkingFromModel(modelName string) *ThinkingConfig {
+	suffixLevel, explicitBudget, isThinking := util.ParseThinkingSuffixDetailed(modelName)
 	if !isThinking {
 		return nil
 	}
 
-	budget, _ := util.GetThinkingBudget(modelName, suffixLevel, 0)
+	budget, _ := util.GetThinkingBudget(modelName, suffixLevel, explicitBudget)
 	if budget <= 0 {
 		return nil
 	}
@@ -32,6 +40,39 @@ func ParseClaudeThinkingFromModel(modelName string) *ThinkingConfig {
 	return &ThinkingConfig{
 		Enabled:      true,
 		BudgetTokens: budget,
+		Level:        suffixLevel,
+	}
+}
+
+// ParseClaudeThinkingFromModel is retained for existing Claude call sites;
+// it is now a thin alias over the provider-agnostic ParseThinkingFromModel.
+//
+// Deprecated: use ParseThinkingFromModel.
+func ParseClaudeThinkingFromModel(modelName string) *ThinkingConfig {
+	return ParseThinkingFromModel(modelName)
+}
+
+// ApplyThinking resolves the thinking configuration for model and applies
+// it to body in whichever wire format that model's registered provider
+// expects, so callers don't need to know the provider ahead of time.
+func ApplyThinking(model string, body []byte) []byte {
+	cfg := ParseThinkingFromModel(model)
+	if cfg == nil {
+		return body
+	}
+
+	providerName := ""
+	if info := registry.GetGlobalRegistry().GetModelInfo(model); info != nil {
+		providerName = info.Provider
+	}
+
+	switch providerName {
+	case "gemini", "antigravity", "gemini-cli":
+		return cfg.ApplyToGemini(body)
+	case "openai", "github-copilot":
+		return cfg.ApplyToOpenAI(body)
+	default:
+		return cfg.ApplyToClaude(body)
 	}
 }
 
@@ -50,6 +91,80 @@ func (t *ThinkingConfig) ApplyToClaude(body []byte) []byte {
 	return body
 }
 
+// ApplyToGemini applies thinking configuration to a Gemini request body,
+// setting generationConfig.thinkingConfig.thinkingBudget/includeThoughts.
+// Transparently handles the nested request.generationConfig.* variant used
+// by Antigravity/GeminiCLI payloads (see extractContentsFromPayload in
+// internal/util). If thinkingConfig already exists in the body, returns the
+// body unchanged.
+func (t *ThinkingConfig) ApplyToGemini(body []byte) []byte {
+	if t == nil || !t.Enabled {
+		return body
+	}
+
+	prefix := "generationConfig."
+	if gjson.GetBytes(body, "request.generationConfig").Exists() || gjson.GetBytes(body, "request.contents").Exists() {
+		prefix = "request.generationConfig."
+	}
+
+	if gjson.GetBytes(body, prefix+"thinkingConfig").Exists() {
+		return body
+	}
+
+	body, _ = sjson.SetBytes(body, prefix+"thinkingConfig.thinkingBudget", t.BudgetTokens)
+	body, _ = sjson.SetBytes(body, prefix+"thinkingConfig.includeThoughts", true)
+	return body
+}
+
+// ApplyToOpenAI applies thinking configuration to an OpenAI-style request
+// body. OpenAI's reasoning models don't take a raw token budget, so the
+// registry's Low/Medium/High/Max levels are mapped to reasoning_effort
+// ("low"|"medium"|"high", with Max folding into "high" plus an explicit
+// reasoning.max_tokens). Both the legacy top-level reasoning_effort field
+// and the newer nested reasoning.effort field are emitted for compatibility
+// with older and newer OpenAI-compatible backends. If either field already
+// exists in the body, returns the body unchanged.
+func (t *ThinkingConfig) ApplyToOpenAI(body []byte) []byte {
+	if t == nil || !t.Enabled {
+		return body
+	}
+	if gjson.GetBytes(body, "reasoning_effort").Exists() || gjson.GetBytes(body, "reasoning.effort").Exists() {
+		return body
+	}
+
+	effort := openAIReasoningEffort(t.Level, t.BudgetTokens)
+	body, _ = sjson.SetBytes(body, "reasoning_effort", effort)
+	body, _ = sjson.SetBytes(body, "reasoning.effort", effort)
+	if t.Level == util.ThinkingLevelMax && t.BudgetTokens > 0 {
+		body, _ = sjson.SetBytes(body, "reasoning.max_tokens", t.BudgetTokens)
+	}
+	return body
+}
+
+// openAIReasoningEffort maps a thinking level to OpenAI's three-tier
+// reasoning_effort. When level is unknown (e.g. an auto-applied default
+// with no suffix), it buckets by budget using the same tier boundaries as
+// util.DefaultThinkingBudgets.
+func openAIReasoningEffort(level util.ThinkingLevel, budget int) string {
+	switch level {
+	case util.ThinkingLevelLow:
+		return "low"
+	case util.ThinkingLevelMedium:
+		return "medium"
+	case util.ThinkingLevelHigh, util.ThinkingLevelMax:
+		return "high"
+	}
+
+	switch {
+	case budget <= util.DefaultThinkingBudgets.Low:
+		return "low"
+	case budget <= util.DefaultThinkingBudgets.Medium:
+		return "medium"
+	default:
+		return "high"
+	}
+}
+
 // EnsureClaudeMaxTokens ensures max_tokens is sufficient for thinking mode.
 // Claude requires max_tokens >= budget_tokens + response_buffer when thinking is enabled.
 func EnsureClaudeMaxTokens(modelName string, body []byte) []byte {
@@ -87,7 +202,7 @@ func ApplyThinkingToIR(model string, req *ir.UnifiedChatRequest) {
 		return
 	}
 
-	cfg := ParseClaudeThinkingFromModel(model)
+	cfg := ParseThinkingFromModel(model)
 	if cfg == nil {
 		return
 	}
@@ -0,0 +1,126 @@
+package providers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nghyane/llm-mux/internal/registry"
+	"github.com/nghyane/llm-mux/internal/util"
+)
+
+func TestApplyToGemini_SetsThinkingConfig(t *testing.T) {
+	cfg := &ThinkingConfig{Enabled: true, BudgetTokens: 4096}
+
+	body := cfg.ApplyToGemini([]byte(`{"contents":[]}`))
+
+	if !strings.Contains(string(body), `"thinkingBudget":4096`) {
+		t.Errorf("body = %s, want thinkingBudget:4096", body)
+	}
+	if !strings.Contains(string(body), `"includeThoughts":true`) {
+		t.Errorf("body = %s, want includeThoughts:true", body)
+	}
+}
+
+func TestApplyToGemini_UsesNestedRequestVariant(t *testing.T) {
+	cfg := &ThinkingConfig{Enabled: true, BudgetTokens: 2048}
+
+	// An existing (even empty) request.generationConfig object, like a
+	// request.contents array, signals the Antigravity/GeminiCLI nested wire
+	// format; ApplyToGemini
+	// must write the thinking config under that nested path rather than a
+	// new top-level generationConfig.
+	body := cfg.ApplyToGemini([]byte(`{"request":{"contents":[],"generationConfig":{}}}`))
+
+	if !strings.Contains(string(body), `"request":{"contents":[],"generationConfig":{"thinkingConfig":{"thinkingBudget":2048`) {
+		t.Errorf("body = %s, want nested request.generationConfig.thinkingConfig", body)
+	}
+}
+
+func TestApplyToGemini_Idempotent(t *testing.T) {
+	cfg := &ThinkingConfig{Enabled: true, BudgetTokens: 4096}
+
+	existing := []byte(`{"generationConfig":{"thinkingConfig":{"thinkingBudget":1}}}`)
+	body := cfg.ApplyToGemini(existing)
+
+	if string(body) != string(existing) {
+		t.Errorf("body = %s, want unchanged %s", body, existing)
+	}
+}
+
+func TestApplyToOpenAI_MapsLevelsToReasoningEffort(t *testing.T) {
+	cases := []struct {
+		level util.ThinkingLevel
+		want  string
+	}{
+		{util.ThinkingLevelLow, "low"},
+		{util.ThinkingLevelMedium, "medium"},
+		{util.ThinkingLevelHigh, "high"},
+		{util.ThinkingLevelMax, "high"},
+	}
+	for _, tc := range cases {
+		cfg := &ThinkingConfig{Enabled: true, BudgetTokens: 8192, Level: tc.level}
+		body := cfg.ApplyToOpenAI([]byte(`{}`))
+		want := `"reasoning_effort":"` + tc.want + `"`
+		if !strings.Contains(string(body), want) {
+			t.Errorf("level %s: body = %s, want %s", tc.level, body, want)
+		}
+	}
+}
+
+func TestApplyToOpenAI_MaxIncludesReasoningMaxTokens(t *testing.T) {
+	cfg := &ThinkingConfig{Enabled: true, BudgetTokens: 32768, Level: util.ThinkingLevelMax}
+
+	body := cfg.ApplyToOpenAI([]byte(`{}`))
+
+	if !strings.Contains(string(body), `"max_tokens":32768`) {
+		t.Errorf("body = %s, want reasoning.max_tokens:32768", body)
+	}
+}
+
+func TestApplyToOpenAI_Idempotent(t *testing.T) {
+	cfg := &ThinkingConfig{Enabled: true, BudgetTokens: 8192, Level: util.ThinkingLevelHigh}
+
+	existing := []byte(`{"reasoning_effort":"low"}`)
+	body := cfg.ApplyToOpenAI(existing)
+
+	if string(body) != string(existing) {
+		t.Errorf("body = %s, want unchanged %s", body, existing)
+	}
+}
+
+func TestApplyThinking_DispatchesByProvider(t *testing.T) {
+	registerTestModel(t, "test-gemini-thinking-high", &registry.ModelInfo{
+		Provider: "gemini",
+		Thinking: &registry.ThinkingSettings{Min: 1024, Max: 32768, Budgets: registry.ThinkingBudgets{High: 16384}},
+	})
+	registerTestModel(t, "test-openai-thinking-high", &registry.ModelInfo{
+		Provider: "openai",
+		Thinking: &registry.ThinkingSettings{Min: 1024, Max: 32768, Budgets: registry.ThinkingBudgets{High: 16384}},
+	})
+	registerTestModel(t, "test-claude-thinking-high", &registry.ModelInfo{
+		Provider: "claude",
+		Thinking: &registry.ThinkingSettings{Min: 1024, Max: 32768, Budgets: registry.ThinkingBudgets{High: 16384}},
+	})
+
+	geminiBody := ApplyThinking("test-gemini-thinking-high", []byte(`{}`))
+	if !strings.Contains(string(geminiBody), "thinkingConfig") {
+		t.Errorf("gemini body = %s, want thinkingConfig applied", geminiBody)
+	}
+
+	openAIBody := ApplyThinking("test-openai-thinking-high", []byte(`{}`))
+	if !strings.Contains(string(openAIBody), "reasoning_effort") {
+		t.Errorf("openai body = %s, want reasoning_effort applied", openAIBody)
+	}
+
+	claudeBody := ApplyThinking("test-claude-thinking-high", []byte(`{}`))
+	if !strings.Contains(string(claudeBody), `"thinking"`) {
+		t.Errorf("claude body = %s, want thinking applied", claudeBody)
+	}
+}
+
+func TestApplyThinking_NoSuffixReturnsBodyUnchanged(t *testing.T) {
+	body := ApplyThinking("no-suffix-model", []byte(`{"foo":"bar"}`))
+	if string(body) != `{"foo":"bar"}` {
+		t.Errorf("body = %s, want unchanged", body)
+	}
+}
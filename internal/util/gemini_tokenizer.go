@@ -1,6 +1,9 @@
 package util
 
 import (
+	"container/list"
+	"encoding/json"
+	"math"
 	"sync"
 
 	"github.com/tidwall/gjson"
@@ -8,37 +11,66 @@ import (
 	"google.golang.org/genai/tokenizer"
 )
 
+// tokenizerCacheCapacity bounds the number of distinct tokenizer instances
+// kept alive at once. Tokenizers are cheap to recreate but not free, and
+// callers may see many distinct model names over the life of the process
+// (per-request model overrides, thinking suffixes, etc.), so this is an LRU
+// rather than an unbounded cache.
+const tokenizerCacheCapacity = 32
+
+// tokenizerCacheEntry is the value stored in tokenizerCacheList; model is
+// kept alongside the tokenizer so the index can be cleaned up on evict.
+type tokenizerCacheEntry struct {
+	model string
+	tok   *tokenizer.LocalTokenizer
+}
+
 var (
-	// tokenizerCache caches tokenizers by model name
-	tokenizerCache   = make(map[string]*tokenizer.LocalTokenizer)
-	tokenizerCacheMu sync.RWMutex
+	tokenizerCacheMu    sync.Mutex
+	tokenizerCacheList  = list.New()
+	tokenizerCacheIndex = make(map[string]*list.Element)
 )
 
-// getOrCreateTokenizer returns a cached tokenizer or creates a new one.
+// getOrCreateTokenizer returns a cached tokenizer or creates a new one,
+// evicting the least-recently-used entry once tokenizerCacheCapacity is
+// exceeded.
 func getOrCreateTokenizer(model string) (*tokenizer.LocalTokenizer, error) {
 	// Normalize model name for tokenizer (use base model)
 	baseModel := normalizeModelForTokenizer(model)
 
-	tokenizerCacheMu.RLock()
-	tok, ok := tokenizerCache[baseModel]
-	tokenizerCacheMu.RUnlock()
-	if ok {
+	tokenizerCacheMu.Lock()
+	if elem, ok := tokenizerCacheIndex[baseModel]; ok {
+		tokenizerCacheList.MoveToFront(elem)
+		tok := elem.Value.(*tokenizerCacheEntry).tok
+		tokenizerCacheMu.Unlock()
 		return tok, nil
 	}
+	tokenizerCacheMu.Unlock()
+
+	tok, err := tokenizer.NewLocalTokenizer(baseModel)
+	if err != nil {
+		return nil, err
+	}
 
 	tokenizerCacheMu.Lock()
 	defer tokenizerCacheMu.Unlock()
 
-	// Double-check after acquiring write lock
-	if tok, ok := tokenizerCache[baseModel]; ok {
-		return tok, nil
+	// Double-check after re-acquiring the lock.
+	if elem, ok := tokenizerCacheIndex[baseModel]; ok {
+		tokenizerCacheList.MoveToFront(elem)
+		return elem.Value.(*tokenizerCacheEntry).tok, nil
 	}
 
-	tok, err := tokenizer.NewLocalTokenizer(baseModel)
-	if err != nil {
-		return nil, err
+	elem := tokenizerCacheList.PushFront(&tokenizerCacheEntry{model: baseModel, tok: tok})
+	tokenizerCacheIndex[baseModel] = elem
+
+	if tokenizerCacheList.Len() > tokenizerCacheCapacity {
+		if oldest := tokenizerCacheList.Back(); oldest != nil {
+			tokenizerCacheList.Remove(oldest)
+			delete(tokenizerCacheIndex, oldest.Value.(*tokenizerCacheEntry).model)
+		}
 	}
-	tokenizerCache[baseModel] = tok
+
 	return tok, nil
 }
 
@@ -70,6 +102,20 @@ func containsAny(s string, substrs ...string) bool {
 	return false
 }
 
+// Token cost constants for non-text parts, following Gemini's published
+// per-modality rules. These are estimates: the authoritative cost is
+// computed server-side, but they keep routing/context-window decisions in
+// the right ballpark instead of silently charging zero.
+const (
+	imageTokensPerTile   = 258
+	imageTileSize        = 768
+	imageFallbackTokens  = 258
+	audioTokensPerSecond = 32
+	audioFallbackTokens  = 32 * 30 // ~30s clip when duration is unknown
+	videoTokensPerSecond = 263
+	videoFallbackTokens  = 263 * 10 // ~10s clip when duration is unknown
+)
+
 // CountTokensFromGeminiRequest counts tokens from a Gemini API request payload.
 // Returns the token count or 0 if counting fails (non-blocking).
 func CountTokensFromGeminiRequest(model string, payload []byte) int64 {
@@ -78,84 +124,226 @@ func CountTokensFromGeminiRequest(model string, payload []byte) int64 {
 		return 0 // Fail silently, return 0
 	}
 
-	contents := extractContentsFromPayload(payload)
-	if len(contents) == 0 {
-		return 0
-	}
+	contents, mediaTokens := extractContentsFromPayload(payload)
 
-	result, err := tok.CountTokens(contents, nil)
-	if err != nil {
-		return 0
+	var textTokens int64
+	if len(contents) > 0 {
+		result, err := tok.CountTokens(contents, nil)
+		if err != nil {
+			return 0
+		}
+		textTokens = int64(result.TotalTokens)
 	}
 
-	return int64(result.TotalTokens)
+	return textTokens + mediaTokens
 }
 
-// extractContentsFromPayload extracts genai.Content from Gemini request payload.
+// extractContentsFromPayload extracts genai.Content from a Gemini request
+// payload, plus the summed token cost of any image/audio/video parts that
+// the local tokenizer can't account for directly.
 // Supports both standard Gemini format and Antigravity/GeminiCLI format (nested in "request").
-func extractContentsFromPayload(payload []byte) []*genai.Content {
+func extractContentsFromPayload(payload []byte) ([]*genai.Content, int64) {
 	var contents []*genai.Content
+	var mediaTokens int64
 
 	// Check if contents is nested under "request" (Antigravity/GeminiCLI format)
 	contentsPath := "contents"
 	systemPath := "systemInstruction"
+	toolsPath := "tools"
+	cachedContentPath := "cachedContent"
 	if gjson.GetBytes(payload, "request.contents").Exists() {
 		contentsPath = "request.contents"
 		systemPath = "request.systemInstruction"
+		toolsPath = "request.tools"
+		cachedContentPath = "request.cachedContent"
 	}
 
 	// Extract system instruction if present
 	systemInstruction := gjson.GetBytes(payload, systemPath)
 	if systemInstruction.Exists() {
-		if content := parseContent(systemInstruction, "user"); content != nil {
+		if content, extra := parseContent(systemInstruction, "user"); content != nil {
 			contents = append(contents, content)
+			mediaTokens += extra
 		}
 	}
 
 	// Extract contents array
 	contentsArr := gjson.GetBytes(payload, contentsPath)
-	if !contentsArr.IsArray() {
-		return contents
+	if contentsArr.IsArray() {
+		contentsArr.ForEach(func(_, value gjson.Result) bool {
+			role := value.Get("role").String()
+			if role == "" {
+				role = "user"
+			}
+			if content, extra := parseContent(value, role); content != nil {
+				contents = append(contents, content)
+				mediaTokens += extra
+			}
+			return true
+		})
 	}
 
-	contentsArr.ForEach(func(_, value gjson.Result) bool {
-		role := value.Get("role").String()
-		if role == "" {
-			role = "user"
-		}
-		if content := parseContent(value, role); content != nil {
-			contents = append(contents, content)
+	// Tool declarations and a referenced cachedContent both consume context
+	// even though they aren't "contents" in the conversational sense, so
+	// fold the serialized tool schemas and the cachedContent value as it
+	// appears in the payload in as synthetic system-role content. The cached
+	// content itself is not fetched, so its tokens are not counted.
+	if synthetic := synthesizeToolsContent(gjson.GetBytes(payload, toolsPath)); synthetic != nil {
+		contents = append(contents, synthetic)
+	}
+	if cachedContent := gjson.GetBytes(payload, cachedContentPath); cachedContent.Exists() {
+		if synthetic := synthesizeTextContent("system", cachedContent.Raw); synthetic != nil {
+			contents = append(contents, synthetic)
 		}
-		return true
-	})
+	}
 
-	return contents
+	return contents, mediaTokens
 }
 
-// parseContent parses a gjson.Result into genai.Content.
-func parseContent(value gjson.Result, role string) *genai.Content {
+// parseContent parses a gjson.Result into genai.Content, along with the
+// summed token cost of any image/audio/video parts it contains.
+func parseContent(value gjson.Result, role string) (*genai.Content, int64) {
 	parts := value.Get("parts")
 	if !parts.IsArray() {
-		return nil
+		return nil, 0
 	}
 
 	var genaiParts []*genai.Part
+	var mediaTokens int64
 	parts.ForEach(func(_, part gjson.Result) bool {
-		// Handle text parts
-		if text := part.Get("text"); text.Exists() {
-			genaiParts = append(genaiParts, genai.NewPartFromText(text.String()))
+		switch {
+		case part.Get("text").Exists():
+			// "thought" is a boolean flag Gemini sets alongside text on a
+			// thinking part ({"text": "...", "thought": true}), not a
+			// separate string field - the text itself is what needs
+			// tokenizing either way.
+			genaiParts = append(genaiParts, genai.NewPartFromText(part.Get("text").String()))
+		case part.Get("inlineData").Exists():
+			mediaTokens += mediaTokensForBlob(part.Get("inlineData"))
+		case part.Get("fileData").Exists():
+			mediaTokens += mediaTokensForBlob(part.Get("fileData"))
+		case part.Get("functionCall").Exists():
+			if text := serializeJSON(part.Get("functionCall").Raw); text != "" {
+				genaiParts = append(genaiParts, genai.NewPartFromText(text))
+			}
+		case part.Get("functionResponse").Exists():
+			if text := serializeJSON(part.Get("functionResponse").Raw); text != "" {
+				genaiParts = append(genaiParts, genai.NewPartFromText(text))
+			}
 		}
-		// Note: Images/audio would need different handling
-		// For now, we only count text tokens (most accurate for context window)
 		return true
 	})
 
 	if len(genaiParts) == 0 {
-		return nil
+		return nil, mediaTokens
 	}
 
 	return &genai.Content{
 		Role:  role,
 		Parts: genaiParts,
+	}, mediaTokens
+}
+
+// mediaTokensForBlob estimates the token cost of an inlineData/fileData
+// part based on its mimeType, following Gemini's tile-based image rule and
+// per-second audio/video rules. Falls back to a conservative fixed estimate
+// when dimensions or duration aren't present in the payload.
+func mediaTokensForBlob(blob gjson.Result) int64 {
+	mimeType := blob.Get("mimeType").String()
+
+	switch {
+	case hasPrefix(mimeType, "image/"):
+		width := blob.Get("width").Int()
+		height := blob.Get("height").Int()
+		if width > 0 && height > 0 {
+			tilesX := math.Ceil(float64(width) / imageTileSize)
+			tilesY := math.Ceil(float64(height) / imageTileSize)
+			return int64(tilesX*tilesY) * imageTokensPerTile
+		}
+		return imageFallbackTokens
+	case hasPrefix(mimeType, "audio/"):
+		if seconds := blob.Get("durationSeconds").Num; seconds > 0 {
+			return int64(math.Ceil(seconds)) * audioTokensPerSecond
+		}
+		return audioFallbackTokens
+	case hasPrefix(mimeType, "video/"):
+		if seconds := blob.Get("durationSeconds").Num; seconds > 0 {
+			return int64(math.Ceil(seconds)) * videoTokensPerSecond
+		}
+		return videoFallbackTokens
+	default:
+		return 0
+	}
+}
+
+func hasPrefix(s, prefix string) bool {
+	return len(s) >= len(prefix) && s[:len(prefix)] == prefix
+}
+
+// synthesizeToolsContent serializes tools[].functionDeclarations into a
+// synthetic system-role content block so its schema size is reflected in
+// the token count.
+func synthesizeToolsContent(tools gjson.Result) *genai.Content {
+	if !tools.IsArray() {
+		return nil
+	}
+
+	var raw []string
+	tools.ForEach(func(_, tool gjson.Result) bool {
+		decls := tool.Get("functionDeclarations")
+		if decls.IsArray() {
+			decls.ForEach(func(_, decl gjson.Result) bool {
+				raw = append(raw, decl.Raw)
+				return true
+			})
+		}
+		return true
+	})
+	if len(raw) == 0 {
+		return nil
+	}
+
+	return synthesizeTextContent("system", "["+joinRaw(raw)+"]")
+}
+
+// synthesizeTextContent wraps a raw JSON/text string as a single-part
+// genai.Content, returning nil for empty input.
+func synthesizeTextContent(role, text string) *genai.Content {
+	if text == "" {
+		return nil
+	}
+	return &genai.Content{
+		Role:  role,
+		Parts: []*genai.Part{genai.NewPartFromText(text)},
+	}
+}
+
+// serializeJSON re-marshals a gjson raw value through encoding/json to
+// produce a stable, compact string; returns the raw input unchanged if it
+// isn't valid JSON, and "" for empty input.
+func serializeJSON(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	var v any
+	if err := json.Unmarshal([]byte(raw), &v); err != nil {
+		return raw
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return raw
+	}
+	return string(b)
+}
+
+// joinRaw joins raw JSON fragments with commas.
+func joinRaw(raw []string) string {
+	out := ""
+	for i, r := range raw {
+		if i > 0 {
+			out += ","
+		}
+		out += r
 	}
+	return out
 }
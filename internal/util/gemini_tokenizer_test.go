@@ -0,0 +1,99 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestMediaTokensForBlob_Image(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want int64
+	}{
+		{"no dimensions falls back", `{"mimeType":"image/png"}`, imageFallbackTokens},
+		{"single tile", `{"mimeType":"image/png","width":768,"height":768}`, imageTokensPerTile},
+		{"rounds up to next tile", `{"mimeType":"image/png","width":800,"height":768}`, 2 * imageTokensPerTile},
+		{"multiple tiles both axes", `{"mimeType":"image/png","width":1536,"height":1536}`, 4 * imageTokensPerTile},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mediaTokensForBlob(gjson.Parse(tt.raw))
+			if got != tt.want {
+				t.Errorf("mediaTokensForBlob(%s) = %d, want %d", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMediaTokensForBlob_Audio(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want int64
+	}{
+		{"no duration falls back", `{"mimeType":"audio/wav"}`, audioFallbackTokens},
+		{"ten seconds", `{"mimeType":"audio/wav","durationSeconds":10}`, 10 * audioTokensPerSecond},
+		{"rounds up fractional seconds", `{"mimeType":"audio/wav","durationSeconds":2.1}`, 3 * audioTokensPerSecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mediaTokensForBlob(gjson.Parse(tt.raw))
+			if got != tt.want {
+				t.Errorf("mediaTokensForBlob(%s) = %d, want %d", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMediaTokensForBlob_Video(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want int64
+	}{
+		{"no duration falls back", `{"mimeType":"video/mp4"}`, videoFallbackTokens},
+		{"five seconds", `{"mimeType":"video/mp4","durationSeconds":5}`, 5 * videoTokensPerSecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mediaTokensForBlob(gjson.Parse(tt.raw))
+			if got != tt.want {
+				t.Errorf("mediaTokensForBlob(%s) = %d, want %d", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMediaTokensForBlob_UnknownMimeType(t *testing.T) {
+	got := mediaTokensForBlob(gjson.Parse(`{"mimeType":"application/pdf"}`))
+	if got != 0 {
+		t.Errorf("mediaTokensForBlob(pdf) = %d, want 0", got)
+	}
+}
+
+func TestParseContent_ThoughtIsTextFlagNotSeparateField(t *testing.T) {
+	value := gjson.Parse(`{"parts":[{"text":"reasoning...","thought":true}]}`)
+	content, extra := parseContent(value, "model")
+	if content == nil || len(content.Parts) != 1 {
+		t.Fatalf("expected one text part, got %+v", content)
+	}
+	if content.Parts[0].Text != "reasoning..." {
+		t.Errorf("got text %q, want %q", content.Parts[0].Text, "reasoning...")
+	}
+	if extra != 0 {
+		t.Errorf("expected no media tokens for a thought/text part, got %d", extra)
+	}
+}
+
+func TestParseContent_FunctionCallSerializedAsText(t *testing.T) {
+	value := gjson.Parse(`{"parts":[{"functionCall":{"name":"lookup","args":{"q":"weather"}}}]}`)
+	content, _ := parseContent(value, "model")
+	if content == nil || len(content.Parts) != 1 {
+		t.Fatalf("expected one text part, got %+v", content)
+	}
+	if content.Parts[0].Text == "" {
+		t.Error("expected functionCall to be serialized as text, got empty part")
+	}
+}
@@ -1,6 +1,7 @@
 package util
 
 import (
+	"strconv"
 	"strings"
 
 	"github.com/nghyane/llm-mux/internal/registry"
@@ -28,6 +29,10 @@ const (
 	ThinkingLevelMedium ThinkingLevel = "medium"
 	ThinkingLevelHigh   ThinkingLevel = "high"
 	ThinkingLevelMax    ThinkingLevel = "max"
+	// ThinkingLevelExact marks a model name suffix that specified a
+	// concrete budget directly (-thinking-<int> or -thinking-<int>%)
+	// rather than a named level. See ParseThinkingSuffixDetailed.
+	ThinkingLevelExact ThinkingLevel = "exact"
 )
 
 // ModelSupportsThinking reports whether the given model has Thinking capability
@@ -77,30 +82,89 @@ func GetAutoAppliedThinkingConfig(model string) (int, bool, bool) {
 }
 
 // ParseThinkingSuffix extracts thinking level from model name suffix.
-// Returns (level, is_thinking_model).
+// Returns (level, is_thinking_model). Kept for existing callers that don't
+// need the explicit budget; wraps ParseThinkingSuffixDetailed.
 func ParseThinkingSuffix(modelName string) (ThinkingLevel, bool) {
+	level, _, ok := ParseThinkingSuffixDetailed(modelName)
+	return level, ok
+}
+
+// ParseThinkingSuffixDetailed extracts thinking configuration from a model
+// name suffix. In addition to the four named levels, it recognizes an
+// explicit budget so users don't have to edit the registry for one-off
+// requests:
+//   - "-thinking-<int>" sets an exact token budget, e.g.
+//     "claude-sonnet-4-thinking-16384" -> 16384 tokens.
+//   - "-thinking-<int>%" sets a percentage of the model's registry Max,
+//     e.g. "-thinking-50%" -> Max/2.
+//
+// Returns (level, explicitBudget, is_thinking_model). explicitBudget is
+// only meaningful when level is ThinkingLevelExact; callers should pass it
+// straight through to GetThinkingBudget's userBudget parameter, which
+// already treats any positive userBudget as priority 1 and clamps it
+// against the registry's Min/Max.
+func ParseThinkingSuffixDetailed(modelName string) (ThinkingLevel, int, bool) {
 	switch {
 	case strings.HasSuffix(modelName, "-thinking-max"):
-		return ThinkingLevelMax, true
+		return ThinkingLevelMax, 0, true
 	case strings.HasSuffix(modelName, "-thinking-high"):
-		return ThinkingLevelHigh, true
+		return ThinkingLevelHigh, 0, true
 	case strings.HasSuffix(modelName, "-thinking-medium"):
-		return ThinkingLevelMedium, true
+		return ThinkingLevelMedium, 0, true
 	case strings.HasSuffix(modelName, "-thinking-low"):
-		return ThinkingLevelLow, true
+		return ThinkingLevelLow, 0, true
 	case strings.HasSuffix(modelName, "-thinking"):
 		// Default to max level for -thinking suffix
-		return ThinkingLevelMax, true
-	default:
-		return "", false
+		return ThinkingLevelMax, 0, true
+	}
+
+	if idx := strings.LastIndex(modelName, "-thinking-"); idx != -1 {
+		baseModel := modelName[:idx]
+		suffix := modelName[idx+len("-thinking-"):]
+		if budget, ok := parseExplicitThinkingBudget(baseModel, suffix); ok {
+			return ThinkingLevelExact, budget, true
+		}
+	}
+
+	return "", 0, false
+}
+
+// parseExplicitThinkingBudget parses the trailing "-thinking-<suffix>"
+// portion of a model name once the named levels have been ruled out:
+// either a bare integer token count, or an integer percentage of the base
+// model's registry Max thinking budget.
+func parseExplicitThinkingBudget(baseModel, suffix string) (int, bool) {
+	if suffix == "" {
+		return 0, false
+	}
+
+	if strings.HasSuffix(suffix, "%") {
+		pct, err := strconv.Atoi(strings.TrimSuffix(suffix, "%"))
+		if err != nil || pct <= 0 {
+			return 0, false
+		}
+		info := registry.GetGlobalRegistry().GetModelInfo(baseModel)
+		if info == nil || info.Thinking == nil || info.Thinking.Max <= 0 {
+			return 0, false
+		}
+		return info.Thinking.Max * pct / 100, true
+	}
+
+	tokens, err := strconv.Atoi(suffix)
+	if err != nil || tokens <= 0 {
+		return 0, false
 	}
+	return tokens, true
 }
 
 // GetThinkingBudget resolves the thinking budget for a model using single source of truth.
 // Parameters:
 //   - model: model name to resolve budget for
 //   - suffixLevel: optional level from model name suffix (parsed from -thinking-*)
-//   - userBudget: optional user-specified budget (0 means not specified)
+//   - userBudget: optional user-specified budget (0 means not specified).
+//     An explicit budget parsed by ParseThinkingSuffixDetailed (suffixLevel
+//     ThinkingLevelExact) should be passed here too; it is Priority 1 just
+//     like any other user-specified budget.
 //
 // Returns (budget, includeThoughts, isThinking).
 // Uses registry LevelBudgets, falls back to DefaultThinkingBudgets, then Min.
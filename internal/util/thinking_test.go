@@ -0,0 +1,196 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/nghyane/llm-mux/internal/registry"
+)
+
+func registerTestThinkingModel(t *testing.T, name string, ts *registry.ThinkingSettings) {
+	t.Helper()
+	reg := registry.GetGlobalRegistry()
+	reg.Register(name, &registry.ModelInfo{Thinking: ts})
+	t.Cleanup(func() { reg.Register(name, nil) })
+}
+
+func TestParseThinkingSuffixDetailed_NamedLevels(t *testing.T) {
+	tests := []struct {
+		name      string
+		modelName string
+		wantLevel ThinkingLevel
+	}{
+		{"low", "claude-sonnet-4-thinking-low", ThinkingLevelLow},
+		{"medium", "claude-sonnet-4-thinking-medium", ThinkingLevelMedium},
+		{"high", "claude-sonnet-4-thinking-high", ThinkingLevelHigh},
+		{"max", "claude-sonnet-4-thinking-max", ThinkingLevelMax},
+		{"bare thinking defaults to max", "claude-sonnet-4-thinking", ThinkingLevelMax},
+		{"no suffix", "claude-sonnet-4", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			level, budget, ok := ParseThinkingSuffixDetailed(tt.modelName)
+			wantOK := tt.wantLevel != ""
+			if ok != wantOK {
+				t.Fatalf("ParseThinkingSuffixDetailed(%q) ok = %v, want %v", tt.modelName, ok, wantOK)
+			}
+			if level != tt.wantLevel {
+				t.Errorf("ParseThinkingSuffixDetailed(%q) level = %q, want %q", tt.modelName, level, tt.wantLevel)
+			}
+			if budget != 0 {
+				t.Errorf("ParseThinkingSuffixDetailed(%q) budget = %d, want 0", tt.modelName, budget)
+			}
+		})
+	}
+}
+
+func TestParseThinkingSuffixDetailed_ExplicitBudget(t *testing.T) {
+	level, budget, ok := ParseThinkingSuffixDetailed("claude-sonnet-4-thinking-16384")
+	if !ok {
+		t.Fatal("expected is_thinking_model = true")
+	}
+	if level != ThinkingLevelExact {
+		t.Errorf("level = %q, want %q", level, ThinkingLevelExact)
+	}
+	if budget != 16384 {
+		t.Errorf("budget = %d, want 16384", budget)
+	}
+}
+
+func TestParseThinkingSuffixDetailed_PercentageBudget(t *testing.T) {
+	registerTestThinkingModel(t, "claude-sonnet-4", &registry.ThinkingSettings{Min: 1024, Max: 32768})
+
+	level, budget, ok := ParseThinkingSuffixDetailed("claude-sonnet-4-thinking-50%")
+	if !ok {
+		t.Fatal("expected is_thinking_model = true")
+	}
+	if level != ThinkingLevelExact {
+		t.Errorf("level = %q, want %q", level, ThinkingLevelExact)
+	}
+	if budget != 16384 {
+		t.Errorf("budget = %d, want 16384 (50%% of Max 32768)", budget)
+	}
+}
+
+// TestParseThinkingSuffixDetailed_PercentageAgainstRealSeededModel checks the
+// percentage-suffix path against claude-sonnet-4-5, one of the models
+// registry.RegisterDefaults seeds into the real global registry at process
+// init - not a registerTestThinkingModel fixture - so this exercises the
+// same lookup production traffic would hit.
+func TestParseThinkingSuffixDetailed_PercentageAgainstRealSeededModel(t *testing.T) {
+	info := registry.GetGlobalRegistry().GetModelInfo("claude-sonnet-4-5")
+	if info == nil || info.Thinking == nil {
+		t.Fatal("claude-sonnet-4-5 is not seeded in the global registry; registry.RegisterDefaults should run at package init")
+	}
+
+	level, budget, ok := ParseThinkingSuffixDetailed("claude-sonnet-4-5-thinking-50%")
+	if !ok {
+		t.Fatal("expected is_thinking_model = true")
+	}
+	if level != ThinkingLevelExact {
+		t.Errorf("level = %q, want %q", level, ThinkingLevelExact)
+	}
+	want := info.Thinking.Max / 2
+	if budget != want {
+		t.Errorf("budget = %d, want %d (50%% of claude-sonnet-4-5's registered Max)", budget, want)
+	}
+}
+
+func TestParseThinkingSuffixDetailed_PercentageWithoutRegisteredBaseModelFails(t *testing.T) {
+	_, _, ok := ParseThinkingSuffixDetailed("unregistered-model-thinking-50%")
+	if ok {
+		t.Error("expected is_thinking_model = false when the base model has no registry Max to compute a percentage against")
+	}
+}
+
+func TestParseThinkingSuffixDetailed_InvalidSuffixFails(t *testing.T) {
+	tests := []string{
+		"claude-sonnet-4-thinking-",
+		"claude-sonnet-4-thinking-abc",
+		"claude-sonnet-4-thinking-0",
+		"claude-sonnet-4-thinking--5",
+	}
+	for _, modelName := range tests {
+		if _, _, ok := ParseThinkingSuffixDetailed(modelName); ok {
+			t.Errorf("ParseThinkingSuffixDetailed(%q) ok = true, want false", modelName)
+		}
+	}
+}
+
+func TestGetThinkingBudget_UserBudgetTakesPriorityAndClamps(t *testing.T) {
+	registerTestThinkingModel(t, "claude-sonnet-4-thinking-9999999", &registry.ThinkingSettings{Min: 1024, Max: 32768})
+
+	budget, isThinking := GetThinkingBudget("claude-sonnet-4-thinking-9999999", ThinkingLevelExact, 9999999)
+	if !isThinking {
+		t.Fatal("expected isThinking = true")
+	}
+	if budget != 32768 {
+		t.Errorf("budget = %d, want 32768 (clamped to Max)", budget)
+	}
+}
+
+func TestGetThinkingBudget_ClampsBelowMin(t *testing.T) {
+	registerTestThinkingModel(t, "claude-sonnet-4-thinking-1", &registry.ThinkingSettings{Min: 1024, Max: 32768})
+
+	budget, isThinking := GetThinkingBudget("claude-sonnet-4-thinking-1", ThinkingLevelExact, 1)
+	if !isThinking {
+		t.Fatal("expected isThinking = true")
+	}
+	if budget != 1024 {
+		t.Errorf("budget = %d, want 1024 (clamped to Min)", budget)
+	}
+}
+
+func TestGetThinkingBudget_LevelFallsBackToDefaultBudgets(t *testing.T) {
+	registerTestThinkingModel(t, "claude-sonnet-4-thinking-high", &registry.ThinkingSettings{Min: 1024, Max: 32768})
+
+	budget, isThinking := GetThinkingBudget("claude-sonnet-4-thinking-high", ThinkingLevelHigh, 0)
+	if !isThinking {
+		t.Fatal("expected isThinking = true")
+	}
+	if budget != DefaultThinkingBudgets.High {
+		t.Errorf("budget = %d, want %d (DefaultThinkingBudgets.High)", budget, DefaultThinkingBudgets.High)
+	}
+}
+
+func TestGetThinkingBudget_LevelUsesRegistryBudgetsWhenSet(t *testing.T) {
+	registerTestThinkingModel(t, "claude-sonnet-4-thinking-high", &registry.ThinkingSettings{
+		Min: 1024, Max: 32768,
+		Budgets: registry.ThinkingBudgets{High: 20000},
+	})
+
+	budget, _ := GetThinkingBudget("claude-sonnet-4-thinking-high", ThinkingLevelHigh, 0)
+	if budget != 20000 {
+		t.Errorf("budget = %d, want 20000 (registry Budgets.High)", budget)
+	}
+}
+
+func TestGetThinkingBudget_NoSuffixUsesDefaultLevelOrMin(t *testing.T) {
+	registerTestThinkingModel(t, "claude-sonnet-4-with-default-level", &registry.ThinkingSettings{
+		Min: 1024, Max: 32768,
+		Budgets:      registry.ThinkingBudgets{Medium: 8192},
+		DefaultLevel: registry.ThinkingLevelMedium,
+	})
+	registerTestThinkingModel(t, "claude-sonnet-4-without-default-level", &registry.ThinkingSettings{Min: 1024, Max: 32768})
+
+	budget, _ := GetThinkingBudget("claude-sonnet-4-with-default-level", "", 0)
+	if budget != 8192 {
+		t.Errorf("budget = %d, want 8192 (DefaultLevel Medium)", budget)
+	}
+
+	budget, _ = GetThinkingBudget("claude-sonnet-4-without-default-level", "", 0)
+	if budget != 1024 {
+		t.Errorf("budget = %d, want 1024 (Min fallback)", budget)
+	}
+}
+
+func TestGetThinkingBudget_UnregisteredOrNonThinkingModelReturnsFalse(t *testing.T) {
+	if _, isThinking := GetThinkingBudget("not-in-registry", ThinkingLevelHigh, 0); isThinking {
+		t.Error("expected isThinking = false for an unregistered model")
+	}
+
+	registry.GetGlobalRegistry().Register("claude-sonnet-4-no-thinking", &registry.ModelInfo{})
+	t.Cleanup(func() { registry.GetGlobalRegistry().Register("claude-sonnet-4-no-thinking", nil) })
+	if _, isThinking := GetThinkingBudget("claude-sonnet-4-no-thinking", ThinkingLevelHigh, 0); isThinking {
+		t.Error("expected isThinking = false for a model with no Thinking settings")
+	}
+}